@@ -0,0 +1,503 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+)
+
+// queryObj is a single field-level predicate parsed from the shell's
+// query flags, e.g. "ID=10" or "ID<10". fieldName is the Go struct
+// field the predicate refers to; colName is the lower-cased wire
+// column name used when talking to the connector.
+type queryObj struct {
+	fieldName string
+	colName   string
+	op        string
+	valueStr  string
+	value     dosa.FieldValue
+}
+
+// EnforcementMode controls whether a shellQueryClient is allowed to
+// carry out mutating operations (Upsert/Remove) against the
+// underlying connector.
+type EnforcementMode int
+
+const (
+	// Deny rejects all mutations outright. Useful for shared,
+	// read-only CLI sessions where Upsert/Remove should never reach
+	// the connector.
+	Deny EnforcementMode = iota
+	// DryRun logs the resolved EntityInfo/column map that would be
+	// sent to the connector and returns a synthesized success without
+	// calling Upsert/Remove.
+	DryRun
+	// Enforce performs the mutation against the connector. This is
+	// the default mode.
+	Enforce
+)
+
+// shellQueryClient adapts a dosa.Client to the shell's loosely-typed,
+// queryObj-driven Read/Range/Upsert/Remove calls, which operate
+// directly against a connector instead of a generated, typed entity.
+type shellQueryClient struct {
+	dosa.Client
+	connector  dosa.Connector
+	table      *dosa.Table
+	entityInfo *dosa.EntityInfo
+	colToField map[string]string
+	fieldToCol map[string]string
+	mode       EnforcementMode
+	formatter  ResultFormatter
+}
+
+// tableProvider is implemented by registrars, such as simpleRegistrar,
+// that already know the single dosa.Table they back. It lets
+// newShellQueryClient derive column maps without a second registry
+// lookup.
+type tableProvider interface {
+	table() *dosa.Table
+}
+
+// newShellQueryClient builds a shellQueryClient on top of reg and
+// conn. Mutations default to EnforcementMode Enforce.
+func newShellQueryClient(reg dosa.Registrar, conn dosa.Connector) *shellQueryClient {
+	c := &shellQueryClient{
+		Client:    dosa.NewClient(reg, conn),
+		connector: conn,
+		mode:      Enforce,
+		formatter: JSONFormatter{},
+	}
+	if tp, ok := reg.(tableProvider); ok {
+		t := tp.table()
+		c.table = t
+		c.entityInfo = &dosa.EntityInfo{Def: t.EntityDefinition}
+		c.colToField, c.fieldToCol = buildColumnMaps(t)
+	}
+	return c
+}
+
+// SetEnforcementMode changes the EnforcementMode used by subsequent
+// calls to Upsert/Remove.
+func (c *shellQueryClient) SetEnforcementMode(mode EnforcementMode) {
+	c.mode = mode
+}
+
+// SetOutputFormat selects the ResultFormatter subsequent calls to
+// WriteResults use, matching the shell's --output=json|csv|table flag.
+func (c *shellQueryClient) SetOutputFormat(output string) error {
+	f, err := formatterFor(output)
+	if err != nil {
+		return err
+	}
+	c.formatter = f
+	return nil
+}
+
+// WriteResults renders rows, restricted to fields, using the client's
+// configured ResultFormatter (JSON by default).
+func (c *shellQueryClient) WriteResults(rows []map[string]dosa.FieldValue, fields []string, w io.Writer) error {
+	return c.formatter.Format(rows, fields, w)
+}
+
+// buildColumnMaps derives the colName<->fieldName maps for t. Column
+// names are always the lower-cased Go field name, so the original
+// casing (e.g. "ID") is recovered from the table's field list rather
+// than by re-capitalizing the column name.
+func buildColumnMaps(t *dosa.Table) (colToField, fieldToCol map[string]string) {
+	colToField = make(map[string]string)
+	fieldToCol = make(map[string]string)
+	for field, col := range t.FieldToCol {
+		colToField[col] = field
+		fieldToCol[field] = col
+	}
+	return colToField, fieldToCol
+}
+
+// columnsForFields translates a list of Go field names into their
+// wire column names, failing on any field the table doesn't define.
+func (c *shellQueryClient) columnsForFields(fields []string) ([]string, error) {
+	cols := make([]string, len(fields))
+	for i, f := range fields {
+		col, ok := c.fieldToCol[f]
+		if !ok {
+			return nil, errors.Errorf("unknown field %q", f)
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+// convertColToField rewrites each row's keys from wire column names
+// to Go field names, dropping any column the entity doesn't define.
+func convertColToField(rowsCol []map[string]dosa.FieldValue, colToField map[string]string) []map[string]dosa.FieldValue {
+	rowsField := make([]map[string]dosa.FieldValue, len(rowsCol))
+	for i, row := range rowsCol {
+		fieldRow := make(map[string]dosa.FieldValue)
+		for col, val := range row {
+			if field, ok := colToField[col]; ok {
+				fieldRow[field] = val
+			}
+		}
+		rowsField[i] = fieldRow
+	}
+	return rowsField
+}
+
+// buildReadArgs resolves queries into a column/value map suitable for
+// Connector.Read. Read only supports equality lookups, since it
+// addresses a single row by (part of) its primary key.
+func buildReadArgs(queries []*queryObj) (map[string]dosa.FieldValue, error) {
+	args := make(map[string]dosa.FieldValue)
+	for _, q := range queries {
+		if q.op != "eq" {
+			return nil, errors.Errorf("wrong operator used for read: %s", q.op)
+		}
+		args[q.colName] = q.value
+	}
+	return args, nil
+}
+
+// Read resolves queries to a single row via Connector.Read, returning
+// it keyed by Go field name. limit caps the number of rows returned,
+// though a primary-key read never yields more than one.
+func (c *shellQueryClient) Read(ctx context.Context, queries []*queryObj, fields []string, limit int) ([]map[string]dosa.FieldValue, error) {
+	columnValues, err := buildReadArgs(queries)
+	if err != nil {
+		return nil, err
+	}
+	columnsToRead, err := c.columnsForFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.connector.Read(ctx, c.entityInfo, columnValues, columnsToRead)
+	if err != nil {
+		return nil, err
+	}
+	rows := convertColToField([]map[string]dosa.FieldValue{result}, c.colToField)
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// rangeOperators maps the shell's textual operators to dosa.Condition
+// operators accepted by buildRangeOp. "between" is handled separately,
+// since it lowers to two dosa.Conditions on the same column. "in" is
+// not a dosa.Condition at all: a connector's conditions map is AND'd
+// per column, so it is expanded into several Range calls by
+// expandInQueries before queries ever reach buildRangeOp.
+var rangeOperators = map[string]dosa.Operator{
+	"eq":  dosa.Eq,
+	"lt":  dosa.Lt,
+	"gt":  dosa.Gt,
+	"gte": dosa.GtOrEq,
+	"lte": dosa.LtOrEq,
+}
+
+// rangeOp is the subset of dosa.RangeOp behaviour the shell query
+// client needs to drive Connector.Range, built directly from queryObjs
+// rather than a concrete dosa.DomainObject instance.
+type rangeOp struct {
+	conditions map[string][]*dosa.Condition
+	token      string
+	limit      int
+}
+
+// Conditions returns the per-field conditions this rangeOp resolved to.
+func (r *rangeOp) Conditions() map[string][]*dosa.Condition { return r.conditions }
+
+// Token returns the continuation token this rangeOp carries forward
+// to Connector.Range.
+func (r *rangeOp) Token() string { return r.token }
+
+// LimitRows returns the maximum number of rows this rangeOp requests.
+func (r *rangeOp) LimitRows() int { return r.limit }
+
+// buildRangeOp resolves queries into a rangeOp for Connector.Range,
+// threading token through verbatim so callers can page through
+// results. "between" lowers to a gte+lte pair on the same column.
+// "in" queries must already have been removed by expandInQueries;
+// buildRangeOp rejects one as an unsupported operator.
+func buildRangeOp(queries []*queryObj, token string, limit int) (*rangeOp, error) {
+	conditions := make(map[string][]*dosa.Condition)
+	for _, q := range queries {
+		switch q.op {
+		case "between":
+			bounds, ok := q.value.([2]dosa.FieldValue)
+			if !ok {
+				return nil, errors.Errorf("wrong operator used for range: between requires two bounds for %s", q.fieldName)
+			}
+			conditions[q.fieldName] = append(conditions[q.fieldName],
+				&dosa.Condition{Op: dosa.GtOrEq, Value: bounds[0]},
+				&dosa.Condition{Op: dosa.LtOrEq, Value: bounds[1]})
+		default:
+			op, ok := rangeOperators[q.op]
+			if !ok {
+				return nil, errors.Errorf("wrong operator used for range: %s", q.op)
+			}
+			conditions[q.fieldName] = append(conditions[q.fieldName], &dosa.Condition{Op: op, Value: q.value})
+		}
+	}
+	return &rangeOp{conditions: conditions, token: token, limit: limit}, nil
+}
+
+// expandInQueries splits queries on their single "in" clause (if any)
+// into one query slice per value, each carrying a plain "eq" query in
+// its place. A connector's conditions map ANDs every condition listed
+// under a column, so the OR semantics "in" needs can't be expressed in
+// one Range call; instead each value is queried separately and the
+// caller merges rows itself. Returns queries unchanged, wrapped in a
+// single-element slice, when there is no "in" clause.
+func expandInQueries(queries []*queryObj) ([][]*queryObj, error) {
+	inIdx := -1
+	for i, q := range queries {
+		if q.op != "in" {
+			continue
+		}
+		if inIdx != -1 {
+			return nil, errors.New("wrong operator used for range: only one in clause is supported per query")
+		}
+		inIdx = i
+	}
+	if inIdx == -1 {
+		return [][]*queryObj{queries}, nil
+	}
+
+	in := queries[inIdx]
+	values, ok := in.value.([]dosa.FieldValue)
+	if !ok || len(values) == 0 {
+		return nil, errors.Errorf("wrong operator used for range: in requires one or more values for %s", in.fieldName)
+	}
+
+	expanded := make([][]*queryObj, len(values))
+	for i, v := range values {
+		qs := make([]*queryObj, len(queries))
+		copy(qs, queries)
+		qs[inIdx] = &queryObj{fieldName: in.fieldName, colName: in.colName, op: "eq", valueStr: in.valueStr, value: v}
+		expanded[i] = qs
+	}
+	return expanded, nil
+}
+
+// RangePage resolves queries to a single page of rows via
+// Connector.Range, starting from token and returning the connector's
+// next continuation token verbatim. An "in" query is issued as one
+// Range call per value with the results merged client-side; it cannot
+// be combined with a continuation token, since the merged result has
+// no single underlying token to resume from. If any one of those
+// per-value calls comes back with its own continuation token -- i.e.
+// that value alone has more rows than fit in one connector page --
+// RangePage errors instead of silently dropping the remainder, since
+// there is no single merged token a caller could resume from.
+func (c *shellQueryClient) RangePage(ctx context.Context, queries []*queryObj, fields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	columnsToRead, err := c.columnsForFields(fields)
+	if err != nil {
+		return nil, "", err
+	}
+	expanded, err := expandInQueries(queries)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(expanded) > 1 {
+		if token != "" {
+			return nil, "", errors.New("wrong operator used for range: in cannot be combined with a continuation token")
+		}
+		var rowsCol []map[string]dosa.FieldValue
+		for _, qs := range expanded {
+			rop, err := buildRangeOp(qs, "", limit)
+			if err != nil {
+				return nil, "", err
+			}
+			page, pageToken, err := c.connector.Range(ctx, c.entityInfo, rop.Conditions(), columnsToRead, rop.Token(), rop.LimitRows())
+			if err != nil {
+				return nil, "", err
+			}
+			if pageToken != "" {
+				return nil, "", errors.New("wrong operator used for range: in has more rows for one of its values than fit in a single connector page; narrow the limit or query that value separately")
+			}
+			rowsCol = append(rowsCol, page...)
+		}
+		rows := convertColToField(rowsCol, c.colToField)
+		if limit > 0 && len(rows) > limit {
+			rows = rows[:limit]
+		}
+		return rows, "", nil
+	}
+
+	rop, err := buildRangeOp(queries, token, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, nextToken, err := c.connector.Range(ctx, c.entityInfo, rop.Conditions(), columnsToRead, rop.Token(), rop.LimitRows())
+	if err != nil {
+		return nil, "", err
+	}
+	return convertColToField(rows, c.colToField), nextToken, nil
+}
+
+// Range resolves queries to the first page of rows via Connector.Range,
+// returning them keyed by Go field name and discarding the
+// continuation token. Use RangePage or RangeAll to page through
+// further results.
+func (c *shellQueryClient) Range(ctx context.Context, queries []*queryObj, fields []string, limit int) ([]map[string]dosa.FieldValue, error) {
+	rows, _, err := c.RangePage(ctx, queries, fields, "", limit)
+	return rows, err
+}
+
+// RangeAll repeatedly calls RangePage, feeding each page's
+// continuation token back in, until the token comes back empty,
+// hardLimit rows have been accumulated, or ctx is cancelled. It trusts
+// RangePage's "" token to mean no more rows exist, so an "in" query
+// whose per-value results don't all fit in a single connector page
+// surfaces as an error from RangePage rather than a silently
+// truncated result (see RangePage).
+func (c *shellQueryClient) RangeAll(ctx context.Context, queries []*queryObj, fields []string, hardLimit int) ([]map[string]dosa.FieldValue, error) {
+	var all []map[string]dosa.FieldValue
+	token := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return all, err
+		}
+		remaining := hardLimit - len(all)
+		if remaining <= 0 {
+			break
+		}
+		rows, next, err := c.RangePage(ctx, queries, fields, token, remaining)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rows...)
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	return all, nil
+}
+
+// primaryKeyColumns returns the wire column names that make up the
+// table's full primary key (partition key plus clustering keys).
+func (c *shellQueryClient) primaryKeyColumns() []string {
+	key := c.table.EntityDefinition.Key
+	cols := make([]string, 0, len(key.PartitionKeys)+len(key.ClusteringKeys))
+	for _, pk := range key.PartitionKeys {
+		cols = append(cols, strings.ToLower(pk))
+	}
+	for _, ck := range key.ClusteringKeys {
+		cols = append(cols, strings.ToLower(ck.Name))
+	}
+	return cols
+}
+
+// requireFullPrimaryKey fails unless every column of the table's
+// primary key is present in args, since Upsert/Remove must address a
+// single, fully-identified row.
+func (c *shellQueryClient) requireFullPrimaryKey(args map[string]dosa.FieldValue) error {
+	for _, col := range c.primaryKeyColumns() {
+		if _, ok := args[col]; !ok {
+			return errors.Errorf("missing primary key column %q", col)
+		}
+	}
+	return nil
+}
+
+// buildUpsertArgs resolves queries into a column/value map for
+// Connector.Upsert. Every query must be an equality predicate and
+// together they must cover the table's full primary key.
+func (c *shellQueryClient) buildUpsertArgs(queries []*queryObj) (map[string]dosa.FieldValue, error) {
+	args := make(map[string]dosa.FieldValue)
+	for _, q := range queries {
+		if q.op != "eq" {
+			return nil, errors.Errorf("wrong operator used for upsert: %s", q.op)
+		}
+		args[q.colName] = q.value
+	}
+	if err := c.requireFullPrimaryKey(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// buildRemoveArgs resolves queries into a column/value map for
+// Connector.Remove, under the same full-primary-key constraint as
+// buildUpsertArgs.
+func (c *shellQueryClient) buildRemoveArgs(queries []*queryObj) (map[string]dosa.FieldValue, error) {
+	args := make(map[string]dosa.FieldValue)
+	for _, q := range queries {
+		if q.op != "eq" {
+			return nil, errors.Errorf("wrong operator used for remove: %s", q.op)
+		}
+		args[q.colName] = q.value
+	}
+	if err := c.requireFullPrimaryKey(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// mutate applies the client's EnforcementMode around a mutating
+// connector call: Deny rejects op outright, DryRun logs what would be
+// sent and synthesizes success without touching the connector, and
+// Enforce invokes fn.
+func (c *shellQueryClient) mutate(op string, args map[string]dosa.FieldValue, fn func() error) error {
+	switch c.mode {
+	case Deny:
+		return errors.Errorf("%s rejected: client is in Deny enforcement mode", op)
+	case DryRun:
+		log.Printf("dry-run %s: entity=%+v columns=%+v", op, c.entityInfo, args)
+		return nil
+	default:
+		return fn()
+	}
+}
+
+// Upsert writes the column values resolved from queries. queries must
+// cover the table's full primary key with equality predicates;
+// EnforcementMode controls whether the write reaches the connector.
+func (c *shellQueryClient) Upsert(ctx context.Context, queries []*queryObj) error {
+	args, err := c.buildUpsertArgs(queries)
+	if err != nil {
+		return err
+	}
+	return c.mutate("upsert", args, func() error {
+		return c.connector.Upsert(ctx, c.entityInfo, args)
+	})
+}
+
+// Remove deletes the row identified by queries, which must cover the
+// table's full primary key with equality predicates; EnforcementMode
+// controls whether the delete reaches the connector.
+func (c *shellQueryClient) Remove(ctx context.Context, queries []*queryObj) error {
+	args, err := c.buildRemoveArgs(queries)
+	if err != nil {
+		return err
+	}
+	return c.mutate("remove", args, func() error {
+		return c.connector.Remove(ctx, c.entityInfo, args)
+	})
+}
@@ -50,6 +50,7 @@ var (
 	query1        = &queryObj{fieldName: "ID", colName: "id", op: "eq", valueStr: "10", value: dosa.FieldValue(int64(10))}
 	query2        = &queryObj{fieldName: "ID", colName: "id", op: "lt", valueStr: "10", value: dosa.FieldValue(int64(10))}
 	query3        = &queryObj{fieldName: "ID", colName: "id", op: "ne", valueStr: "10", value: dosa.FieldValue(int64(10))}
+	query4        = &queryObj{fieldName: "Email", colName: "email", op: "eq", valueStr: "bar@email.com", value: dosa.FieldValue("bar@email.com")}
 )
 
 func TestNewClient(t *testing.T) {
@@ -211,20 +212,331 @@ func TestClient_BuildReadArgs(t *testing.T) {
 	assert.Contains(t, err.Error(), "wrong operator used for read")
 }
 
+func TestClient_BuildUpsertArgs(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	c := newShellQueryClient(reg, nullConnector)
+
+	// success case, full primary key covered
+	args, err := c.buildUpsertArgs([]*queryObj{query1, query4})
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(10)), args["id"])
+	assert.Equal(t, dosa.FieldValue("bar@email.com"), args["email"])
+
+	// fail case, non-eq operator
+	args, err = c.buildUpsertArgs([]*queryObj{query2})
+	assert.Nil(t, args)
+	assert.Contains(t, err.Error(), "wrong operator used for upsert")
+
+	// fail case, primary key not fully specified
+	args, err = c.buildUpsertArgs([]*queryObj{query4})
+	assert.Nil(t, args)
+	assert.Contains(t, err.Error(), "id")
+}
+
+func TestClient_BuildRemoveArgs(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	c := newShellQueryClient(reg, nullConnector)
+
+	// success case, full primary key covered
+	args, err := c.buildRemoveArgs([]*queryObj{query1})
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(10)), args["id"])
+
+	// fail case, non-eq operator
+	args, err = c.buildRemoveArgs([]*queryObj{query2})
+	assert.Nil(t, args)
+	assert.Contains(t, err.Error(), "wrong operator used for remove")
+
+	// fail case, primary key not fully specified
+	args, err = c.buildRemoveArgs([]*queryObj{query4})
+	assert.Nil(t, args)
+	assert.Contains(t, err.Error(), "id")
+}
+
+func TestClient_Upsert(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+
+	// Enforce: connector is called with the resolved args
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().Upsert(ctx, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *dosa.EntityInfo, columnValues map[string]dosa.FieldValue) {
+			assert.Equal(t, dosa.FieldValue(int64(10)), columnValues["id"])
+			assert.Equal(t, dosa.FieldValue("bar@email.com"), columnValues["email"])
+		}).Return(nil)
+	c := newShellQueryClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+	assert.NoError(t, c.Upsert(ctx, []*queryObj{query1, query4}))
+
+	// DryRun: connector must not be invoked, call still succeeds
+	dryConn := mocks.NewMockConnector(ctrl)
+	dryConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	dc := newShellQueryClient(reg, dryConn)
+	assert.NoError(t, dc.Initialize(ctx))
+	dc.SetEnforcementMode(DryRun)
+	assert.NoError(t, dc.Upsert(ctx, []*queryObj{query1, query4}))
+
+	// Deny: mutation is rejected outright
+	denyConn := mocks.NewMockConnector(ctrl)
+	denyConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	denyC := newShellQueryClient(reg, denyConn)
+	assert.NoError(t, denyC.Initialize(ctx))
+	denyC.SetEnforcementMode(Deny)
+	err := denyC.Upsert(ctx, []*queryObj{query1, query4})
+	assert.Contains(t, err.Error(), "Deny")
+}
+
+func TestClient_Remove(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+
+	// Enforce: connector is called with the resolved args
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().Remove(ctx, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *dosa.EntityInfo, columnValues map[string]dosa.FieldValue) {
+			assert.Equal(t, dosa.FieldValue(int64(10)), columnValues["id"])
+		}).Return(nil)
+	c := newShellQueryClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+	assert.NoError(t, c.Remove(ctx, []*queryObj{query1}))
+
+	// DryRun: connector must not be invoked, call still succeeds
+	dryConn := mocks.NewMockConnector(ctrl)
+	dryConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	dc := newShellQueryClient(reg, dryConn)
+	assert.NoError(t, dc.Initialize(ctx))
+	dc.SetEnforcementMode(DryRun)
+	assert.NoError(t, dc.Remove(ctx, []*queryObj{query1}))
+
+	// Deny: mutation is rejected outright
+	denyConn := mocks.NewMockConnector(ctrl)
+	denyConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	denyC := newShellQueryClient(reg, denyConn)
+	assert.NoError(t, denyC.Initialize(ctx))
+	denyC.SetEnforcementMode(Deny)
+	err := denyC.Remove(ctx, []*queryObj{query1})
+	assert.Contains(t, err.Error(), "Deny")
+}
+
 func TestClient_BuildRangeOp(t *testing.T) {
 	limit := 1
 
 	// success case
-	rop, err := buildRangeOp([]*queryObj{query1, query2}, limit)
+	rop, err := buildRangeOp([]*queryObj{query1, query2}, "", limit)
 	assert.NotNil(t, rop)
 	assert.NoError(t, err)
 	assert.Equal(t, limit, rop.LimitRows())
+	assert.Equal(t, "", rop.Token())
 	conditions := rop.Conditions()
 	assert.Len(t, conditions, 1)
 	assert.Len(t, conditions["ID"], 2)
 
+	// token is threaded through verbatim
+	rop, err = buildRangeOp([]*queryObj{query1}, "cont-token", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, "cont-token", rop.Token())
+
 	// fail case, input non-supported operator
-	rop, err = buildRangeOp([]*queryObj{query3}, limit)
+	rop, err = buildRangeOp([]*queryObj{query3}, "", limit)
+	assert.Nil(t, rop)
+	assert.Contains(t, err.Error(), "wrong operator used for range")
+
+	// gt/gte/lte translate to the matching dosa.Condition operator
+	gtQuery := &queryObj{fieldName: "ID", colName: "id", op: "gt", value: dosa.FieldValue(int64(5))}
+	rop, err = buildRangeOp([]*queryObj{gtQuery}, "", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.Gt, rop.Conditions()["ID"][0].Op)
+
+	gteQuery := &queryObj{fieldName: "ID", colName: "id", op: "gte", value: dosa.FieldValue(int64(5))}
+	rop, err = buildRangeOp([]*queryObj{gteQuery}, "", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.GtOrEq, rop.Conditions()["ID"][0].Op)
+
+	lteQuery := &queryObj{fieldName: "ID", colName: "id", op: "lte", value: dosa.FieldValue(int64(5))}
+	rop, err = buildRangeOp([]*queryObj{lteQuery}, "", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.LtOrEq, rop.Conditions()["ID"][0].Op)
+
+	// in is not a dosa.Condition: a connector's conditions map ANDs
+	// per column, so buildRangeOp rejects it outright. expandInQueries
+	// (exercised separately, and end-to-end via RangePage) is what
+	// turns "in" into several AND-free Range calls.
+	inQuery := &queryObj{fieldName: "ID", colName: "id", op: "in", value: []dosa.FieldValue{int64(1), int64(2), int64(3)}}
+	rop, err = buildRangeOp([]*queryObj{inQuery}, "", limit)
 	assert.Nil(t, rop)
 	assert.Contains(t, err.Error(), "wrong operator used for range")
+
+	// between lowers to a gte+lte pair on the same column
+	betweenQuery := &queryObj{fieldName: "ID", colName: "id", op: "between", value: [2]dosa.FieldValue{int64(1), int64(10)}}
+	rop, err = buildRangeOp([]*queryObj{betweenQuery}, "", limit)
+	assert.NoError(t, err)
+	conditions = rop.Conditions()
+	assert.Len(t, conditions["ID"], 2)
+	assert.Equal(t, dosa.GtOrEq, conditions["ID"][0].Op)
+	assert.Equal(t, dosa.FieldValue(int64(1)), conditions["ID"][0].Value)
+	assert.Equal(t, dosa.LtOrEq, conditions["ID"][1].Op)
+	assert.Equal(t, dosa.FieldValue(int64(10)), conditions["ID"][1].Value)
+
+}
+
+func TestExpandInQueries(t *testing.T) {
+	// no "in" clause: queries pass through unchanged, as a single group
+	groups, err := expandInQueries([]*queryObj{query1})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]*queryObj{{query1}}, groups)
+
+	// "in" expands into one "eq" query per value, leaving other
+	// queries in the group untouched
+	inQuery := &queryObj{fieldName: "ID", colName: "id", op: "in", value: []dosa.FieldValue{int64(1), int64(2), int64(3)}}
+	groups, err = expandInQueries([]*queryObj{inQuery, query4})
+	assert.NoError(t, err)
+	assert.Len(t, groups, 3)
+	for i, want := range []dosa.FieldValue{int64(1), int64(2), int64(3)} {
+		assert.Equal(t, "eq", groups[i][0].op)
+		assert.Equal(t, want, groups[i][0].value)
+		assert.Equal(t, query4, groups[i][1])
+	}
+
+	// fail case, in without values
+	badIn := &queryObj{fieldName: "ID", colName: "id", op: "in", value: []dosa.FieldValue{}}
+	groups, err = expandInQueries([]*queryObj{badIn})
+	assert.Nil(t, groups)
+	assert.Contains(t, err.Error(), "wrong operator used for range")
+
+	// fail case, more than one in clause
+	groups, err = expandInQueries([]*queryObj{inQuery, inQuery})
+	assert.Nil(t, groups)
+	assert.Contains(t, err.Error(), "only one in clause is supported")
+}
+
+func TestClient_BuildReadArgs_RejectsAllButEq(t *testing.T) {
+	for _, op := range []string{"lt", "gt", "gte", "lte", "ne", "in", "between"} {
+		q := &queryObj{fieldName: "ID", colName: "id", op: op, value: dosa.FieldValue(int64(10))}
+		args, err := buildReadArgs([]*queryObj{q})
+		assert.Nil(t, args)
+		assert.Contains(t, err.Error(), "wrong operator used for read: "+op)
+	}
+}
+
+func TestClient_RangePage(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	fieldsToRead := []string{"ID", "Email"}
+	results := map[string]dosa.FieldValue{
+		"id":    int64(2),
+		"name":  "bar",
+		"email": "bar@email.com",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), "page-token", gomock.Any()).
+		Return([]map[string]dosa.FieldValue{results}, "next-token", nil)
+	c := newShellQueryClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	rows, nextToken, err := c.RangePage(ctx, []*queryObj{query1}, fieldsToRead, "page-token", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, "next-token", nextToken)
+}
+
+func TestClient_RangePage_InIsOrMergedAcrossConnectorCalls(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	fieldsToRead := []string{"ID", "Email"}
+	row1 := map[string]dosa.FieldValue{"id": int64(1), "email": "a@email.com"}
+	row2 := map[string]dosa.FieldValue{"id": int64(2), "email": "b@email.com"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	// A connector ANDs every condition listed for a column, so "in"
+	// must surface as one Range call per value, each scoped to a
+	// single eq condition -- never as several stacked eq conditions on
+	// one call, which no row could ever satisfy.
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), "", gomock.Any()).
+		Do(func(_ context.Context, _ *dosa.EntityInfo, conditions map[string][]*dosa.Condition, _ []string, _ string, _ int) {
+			assert.Len(t, conditions["ID"], 1)
+			assert.Equal(t, dosa.Eq, conditions["ID"][0].Op)
+		}).
+		Return([]map[string]dosa.FieldValue{row1}, "", nil)
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), "", gomock.Any()).
+		Do(func(_ context.Context, _ *dosa.EntityInfo, conditions map[string][]*dosa.Condition, _ []string, _ string, _ int) {
+			assert.Len(t, conditions["ID"], 1)
+			assert.Equal(t, dosa.Eq, conditions["ID"][0].Op)
+		}).
+		Return([]map[string]dosa.FieldValue{row2}, "", nil)
+	c := newShellQueryClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	inQuery := &queryObj{fieldName: "ID", colName: "id", op: "in", value: []dosa.FieldValue{int64(1), int64(2)}}
+	rows, nextToken, err := c.RangePage(ctx, []*queryObj{inQuery}, fieldsToRead, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "", nextToken)
+	assert.Equal(t, 2, len(rows))
+
+	// in cannot be resumed from a continuation token, since the merged
+	// result has no single underlying token.
+	_, _, err = c.RangePage(ctx, []*queryObj{inQuery}, fieldsToRead, "some-token", 10)
+	assert.Error(t, err)
+}
+
+func TestClient_RangePage_InErrorsRatherThanSilentlyTruncating(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	fieldsToRead := []string{"ID", "Email"}
+	row1 := map[string]dosa.FieldValue{"id": int64(1), "email": "a@email.com"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	// This value's own results don't fit in a single connector page:
+	// the returned token must not be silently dropped.
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), "", gomock.Any()).
+		Return([]map[string]dosa.FieldValue{row1}, "more-for-this-value", nil).Times(2)
+	c := newShellQueryClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	inQuery := &queryObj{fieldName: "ID", colName: "id", op: "in", value: []dosa.FieldValue{int64(1), int64(2)}}
+	rows, nextToken, err := c.RangePage(ctx, []*queryObj{inQuery}, fieldsToRead, "", 10)
+	assert.Nil(t, rows)
+	assert.Equal(t, "", nextToken)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "more rows")
+
+	// RangeAll must surface the same error rather than trusting a
+	// fabricated "" token and returning a silently-incomplete result.
+	rows, err = c.RangeAll(ctx, []*queryObj{inQuery}, fieldsToRead, 10)
+	assert.Nil(t, rows)
+	assert.Error(t, err)
+}
+
+func TestClient_RangeAll(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	fieldsToRead := []string{"ID", "Email"}
+	page1 := map[string]dosa.FieldValue{"id": int64(1), "email": "a@email.com"}
+	page2 := map[string]dosa.FieldValue{"id": int64(2), "email": "b@email.com"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	first := mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), "", gomock.Any()).
+		Return([]map[string]dosa.FieldValue{page1}, "page-2-token", nil)
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), "page-2-token", gomock.Any()).
+		Return([]map[string]dosa.FieldValue{page2}, "", nil).After(first)
+	c := newShellQueryClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	rows, err := c.RangeAll(ctx, []*queryObj{query1}, fieldsToRead, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, dosa.FieldValue(int64(1)), rows[0]["ID"])
+	assert.Equal(t, dosa.FieldValue(int64(2)), rows[1]["ID"])
 }
@@ -0,0 +1,174 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+)
+
+// ResultFormatter renders rows, restricted to fields in the given
+// order, to w.
+type ResultFormatter interface {
+	Format(rows []map[string]dosa.FieldValue, fields []string, w io.Writer) error
+}
+
+// JSONFormatter renders rows as line-delimited JSON objects, one per
+// row, so output can be piped to jq. A field absent from a row renders
+// as JSON null, the same "missing means empty" convention CSVFormatter
+// and TableFormatter use, rather than being omitted from the object.
+type JSONFormatter struct{}
+
+// Format implements ResultFormatter.
+func (JSONFormatter) Format(rows []map[string]dosa.FieldValue, fields []string, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		obj := make(map[string]dosa.FieldValue, len(fields))
+		for _, f := range fields {
+			obj[f] = row[f]
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVFormatter renders rows as CSV with a header row taken from
+// fields.
+type CSVFormatter struct{}
+
+// Format implements ResultFormatter.
+func (CSVFormatter) Format(rows []map[string]dosa.FieldValue, fields []string, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(fields))
+		for i, f := range fields {
+			record[i] = renderFieldValue(row[f])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderFieldValue renders a single dosa.FieldValue as text, special
+// casing the value types that don't have a sensible default %v form.
+func renderFieldValue(v dosa.FieldValue) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case dosa.UUID:
+		return string(val)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// TableFormatter renders rows as space-aligned ASCII, truncating any
+// cell wider than MaxColWidth. A zero MaxColWidth falls back to
+// defaultTableColWidth.
+type TableFormatter struct {
+	MaxColWidth int
+}
+
+const defaultTableColWidth = 32
+
+// Format implements ResultFormatter.
+func (f TableFormatter) Format(rows []map[string]dosa.FieldValue, fields []string, w io.Writer) error {
+	width := f.MaxColWidth
+	if width <= 0 {
+		width = defaultTableColWidth
+	}
+
+	lines := make([][]string, len(rows)+1)
+	lines[0] = make([]string, len(fields))
+	colWidths := make([]int, len(fields))
+	for i, field := range fields {
+		lines[0][i] = truncateCell(field, width)
+		colWidths[i] = len(lines[0][i])
+	}
+	for r, row := range rows {
+		lines[r+1] = make([]string, len(fields))
+		for i, field := range fields {
+			cell := truncateCell(renderFieldValue(row[field]), width)
+			lines[r+1][i] = cell
+			if len(cell) > colWidths[i] {
+				colWidths[i] = len(cell)
+			}
+		}
+	}
+
+	for _, line := range lines {
+		padded := make([]string, len(line))
+		for i, cell := range line {
+			padded[i] = cell + strings.Repeat(" ", colWidths[i]-len(cell))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(padded, "  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateCell shortens s to at most width characters, replacing the
+// tail with "..." when it doesn't fit.
+func truncateCell(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return s[:width]
+	}
+	return s[:width-3] + "..."
+}
+
+// formatterFor resolves the shell's --output flag to a ResultFormatter.
+// An empty output defaults to JSON.
+func formatterFor(output string) (ResultFormatter, error) {
+	switch output {
+	case "", "json":
+		return JSONFormatter{}, nil
+	case "csv":
+		return CSVFormatter{}, nil
+	case "table":
+		return TableFormatter{}, nil
+	default:
+		return nil, errors.Errorf("unknown output format %q", output)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+)
+
+// formatterFields/formatterRows mirror the results fixture used by
+// TestClient_Read, already converted to Go field names.
+var formatterFields = []string{"ID", "Name", "Email"}
+var formatterRows = []map[string]dosa.FieldValue{
+	{
+		"ID":    dosa.FieldValue(int64(2)),
+		"Name":  dosa.FieldValue("bar"),
+		"Email": dosa.FieldValue("bar@email.com"),
+	},
+}
+
+func TestResultFormatters(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter ResultFormatter
+		want      string
+	}{
+		{"json", JSONFormatter{}, "{\"Email\":\"bar@email.com\",\"ID\":2,\"Name\":\"bar\"}\n"},
+		{"csv", CSVFormatter{}, "ID,Name,Email\n2,bar,bar@email.com\n"},
+		{"table", TableFormatter{}, "ID  Name  Email        \n2   bar   bar@email.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.formatter.Format(formatterRows, formatterFields, &buf)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestResultFormatters_MissingFieldIsEmptyNotOmitted(t *testing.T) {
+	rows := []map[string]dosa.FieldValue{
+		{"ID": dosa.FieldValue(int64(1))},
+	}
+	fields := []string{"ID", "Email"}
+
+	var jsonBuf bytes.Buffer
+	assert.NoError(t, JSONFormatter{}.Format(rows, fields, &jsonBuf))
+	assert.Equal(t, "{\"Email\":null,\"ID\":1}\n", jsonBuf.String())
+
+	var csvBuf bytes.Buffer
+	assert.NoError(t, CSVFormatter{}.Format(rows, fields, &csvBuf))
+	assert.Equal(t, "ID,Email\n1,\n", csvBuf.String())
+
+	var tableBuf bytes.Buffer
+	assert.NoError(t, TableFormatter{}.Format(rows, fields, &tableBuf))
+	assert.Equal(t, "ID  Email\n1        \n", tableBuf.String())
+}
+
+func TestTableFormatter_Truncates(t *testing.T) {
+	rows := []map[string]dosa.FieldValue{
+		{"Name": dosa.FieldValue("a-very-long-value-that-should-be-cut")},
+	}
+	var buf bytes.Buffer
+	err := TableFormatter{MaxColWidth: 10}.Format(rows, []string{"Name"}, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "Name      \na-very-...\n", buf.String())
+}
+
+func TestFormatterFor(t *testing.T) {
+	f, err := formatterFor("json")
+	assert.NoError(t, err)
+	assert.IsType(t, JSONFormatter{}, f)
+
+	f, err = formatterFor("")
+	assert.NoError(t, err)
+	assert.IsType(t, JSONFormatter{}, f)
+
+	f, err = formatterFor("csv")
+	assert.NoError(t, err)
+	assert.IsType(t, CSVFormatter{}, f)
+
+	f, err = formatterFor("table")
+	assert.NoError(t, err)
+	assert.IsType(t, TableFormatter{}, f)
+
+	f, err = formatterFor("xml")
+	assert.Nil(t, f)
+	assert.Contains(t, err.Error(), "unknown output format")
+}
+
+func TestClient_SetOutputFormat(t *testing.T) {
+	reg, _ := newSimpleRegistrar(scope, namePrefix, table)
+	c := newShellQueryClient(reg, nullConnector)
+
+	assert.NoError(t, c.SetOutputFormat("csv"))
+	var buf bytes.Buffer
+	assert.NoError(t, c.WriteResults(formatterRows, formatterFields, &buf))
+	assert.Equal(t, "ID,Name,Email\n2,bar,bar@email.com\n", buf.String())
+
+	assert.Error(t, c.SetOutputFormat("bogus"))
+}
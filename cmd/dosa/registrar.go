@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import "github.com/uber-go/dosa"
+
+// simpleRegistrar is a dosa.Registrar backing exactly one entity. The
+// shell query commands already know which table the caller asked to
+// operate on, so there is no need for the full directory-scanning
+// registrar that the generated clients use.
+type simpleRegistrar struct {
+	scope      string
+	namePrefix string
+	entity     *dosa.Table
+}
+
+// newSimpleRegistrar builds a dosa.Registrar that always resolves to
+// the given table, regardless of which dosa.DomainObject is passed to
+// Find.
+func newSimpleRegistrar(scope, namePrefix string, table *dosa.Table) (dosa.Registrar, error) {
+	return &simpleRegistrar{
+		scope:      scope,
+		namePrefix: namePrefix,
+		entity:     table,
+	}, nil
+}
+
+// ScopeName returns the scope this registrar resolves entities under.
+func (r *simpleRegistrar) ScopeName() string { return r.scope }
+
+// NamePrefix returns the name prefix this registrar resolves entities under.
+func (r *simpleRegistrar) NamePrefix() string { return r.namePrefix }
+
+// Find always returns the single table this registrar was built with.
+func (r *simpleRegistrar) Find(entity dosa.DomainObject) (*dosa.Table, error) {
+	return r.entity, nil
+}
+
+// FindAll returns the single table this registrar was built with.
+func (r *simpleRegistrar) FindAll() ([]*dosa.Table, error) {
+	return []*dosa.Table{r.entity}, nil
+}
+
+// table returns the table backing this registrar. It lets a
+// shellQueryClient built on top of a simpleRegistrar derive its
+// column maps and EntityInfo without a second lookup.
+func (r *simpleRegistrar) table() *dosa.Table {
+	return r.entity
+}